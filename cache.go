@@ -0,0 +1,244 @@
+package changelog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// maxBatchSize is the largest number of commits resolved in a single
+// GraphQL query, since GitHub's API limits a query's field aliases.
+const maxBatchSize = 100
+
+// CommitPRResolver resolves the pull requests (and their authors) associated
+// with a batch of commits. Implementations may memoize results across runs
+// to avoid re-querying GitHub for commits seen in a previous release.
+type CommitPRResolver interface {
+	Resolve(shas []string, repoOwner, repoName string, githubClient *githubv4.Client) (map[string]*commitPRInfo, error)
+}
+
+// cachingResolver is the default CommitPRResolver. It memoizes
+// (owner, repo, sha) -> commitPRInfo in a JSON file under CacheDir, and
+// resolves anything not already cached (or past TTL) with batched GraphQL
+// queries of up to maxBatchSize shas each.
+type cachingResolver struct {
+	// CacheDir is the directory holding the cache file. One file is kept
+	// per owner/repo.
+	CacheDir string
+
+	// TTL is how long a cached entry is trusted before it's re-resolved. A
+	// zero TTL means cached entries never expire.
+	TTL time.Duration
+}
+
+// NewCommitPRResolver returns the default CommitPRResolver, caching entries
+// under cacheDir.
+func NewCommitPRResolver(cacheDir string, ttl time.Duration) CommitPRResolver {
+	return &cachingResolver{CacheDir: cacheDir, TTL: ttl}
+}
+
+// batchingResolver is a CommitPRResolver that batches GraphQL lookups up to
+// maxBatchSize per query, like cachingResolver, but never persists results
+// to disk. It's what -no-cache uses: skip the cache file, but don't give up
+// batching and fall back to one query per commit.
+type batchingResolver struct{}
+
+// NewBatchingResolver returns a CommitPRResolver that batches GitHub GraphQL
+// lookups without caching them to disk.
+func NewBatchingResolver() CommitPRResolver {
+	return &batchingResolver{}
+}
+
+func (b *batchingResolver) Resolve(shas []string, repoOwner, repoName string, githubClient *githubv4.Client) (map[string]*commitPRInfo, error) {
+	result := make(map[string]*commitPRInfo, len(shas))
+	for _, batch := range batchSHAs(shas, maxBatchSize) {
+		fetched, err := prInfoForCommitsBatched(batch, repoOwner, repoName, githubClient)
+		if err != nil {
+			return nil, err
+		}
+		for sha, info := range fetched {
+			result[sha] = info
+		}
+	}
+	return result, nil
+}
+
+type prInfoCacheEntry struct {
+	Info     commitPRInfo
+	CachedAt time.Time
+}
+
+// fresh reports whether e is still trusted under ttl. A zero ttl means
+// entries never expire.
+func (e prInfoCacheEntry) fresh(ttl time.Duration) bool {
+	return ttl <= 0 || time.Since(e.CachedAt) < ttl
+}
+
+type prInfoCacheFile struct {
+	Entries map[string]prInfoCacheEntry
+}
+
+func (c *cachingResolver) cachePath(repoOwner, repoName string) string {
+	return filepath.Join(c.CacheDir, fmt.Sprintf("%s-%s.json", repoOwner, repoName))
+}
+
+func (c *cachingResolver) load(repoOwner, repoName string) (*prInfoCacheFile, error) {
+	contents, err := ioutil.ReadFile(c.cachePath(repoOwner, repoName))
+	if os.IsNotExist(err) {
+		return &prInfoCacheFile{Entries: map[string]prInfoCacheEntry{}}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading PR cache: %s", err)
+	}
+	var cf prInfoCacheFile
+	if err := json.Unmarshal(contents, &cf); err != nil {
+		return nil, fmt.Errorf("parsing PR cache: %s", err)
+	}
+	if cf.Entries == nil {
+		cf.Entries = map[string]prInfoCacheEntry{}
+	}
+	return &cf, nil
+}
+
+func (c *cachingResolver) save(repoOwner, repoName string, cf *prInfoCacheFile) error {
+	if err := os.MkdirAll(c.CacheDir, 0755); err != nil {
+		return fmt.Errorf("creating cache dir %q: %s", c.CacheDir, err)
+	}
+	contents, err := json.Marshal(cf)
+	if err != nil {
+		return fmt.Errorf("encoding PR cache: %s", err)
+	}
+	if err := ioutil.WriteFile(c.cachePath(repoOwner, repoName), contents, 0644); err != nil {
+		return fmt.Errorf("writing PR cache: %s", err)
+	}
+	return nil
+}
+
+func (c *cachingResolver) Resolve(shas []string, repoOwner, repoName string, githubClient *githubv4.Client) (map[string]*commitPRInfo, error) {
+	cf, err := c.load(repoOwner, repoName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*commitPRInfo, len(shas))
+	var misses []string
+	for _, sha := range shas {
+		entry, ok := cf.Entries[sha]
+		if ok && entry.fresh(c.TTL) {
+			info := entry.Info
+			result[sha] = &info
+			continue
+		}
+		misses = append(misses, sha)
+	}
+
+	fetched, err := (&batchingResolver{}).Resolve(misses, repoOwner, repoName, githubClient)
+	if err != nil {
+		return nil, err
+	}
+	for sha, info := range fetched {
+		result[sha] = info
+		cf.Entries[sha] = prInfoCacheEntry{Info: *info, CachedAt: time.Now()}
+	}
+
+	if len(misses) > 0 {
+		if err := c.save(repoOwner, repoName, cf); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func batchSHAs(shas []string, size int) [][]string {
+	var batches [][]string
+	for size < len(shas) {
+		shas, batches = shas[size:], append(batches, shas[0:size:size])
+	}
+	if len(shas) > 0 {
+		batches = append(batches, shas)
+	}
+	return batches
+}
+
+// commitObjectFragment is the shape returned for each aliased commit lookup
+// in a batched query. It matches the single-commit query in prInfoForCommit.
+type commitObjectFragment struct {
+	Commit struct {
+		Author struct {
+			Name githubv4.String
+			User struct {
+				Login githubv4.String
+			}
+		}
+		AssociatedPullRequests struct {
+			Edges []struct {
+				Node struct {
+					Number githubv4.Int
+					Title  githubv4.String
+				}
+			}
+		} `graphql:"associatedPullRequests(first: 1)"`
+	} `graphql:"... on Commit"`
+}
+
+// prInfoForCommitsBatched resolves up to maxBatchSize shas in a single
+// GraphQL query by aliasing each commit lookup as c0, c1, .... The query's
+// shape depends on len(shas), so it's built at runtime with reflect.StructOf
+// rather than a static struct.
+func prInfoForCommitsBatched(shas []string, repoOwner, repoName string, githubClient *githubv4.Client) (map[string]*commitPRInfo, error) {
+	if len(shas) == 0 {
+		return nil, nil
+	}
+
+	fragmentType := reflect.TypeOf(commitObjectFragment{})
+	aliasFields := make([]reflect.StructField, len(shas))
+	variables := map[string]interface{}{
+		"owner": githubv4.String(repoOwner),
+		"name":  githubv4.String(repoName),
+	}
+	for i, sha := range shas {
+		alias := fmt.Sprintf("C%d", i)
+		variables[fmt.Sprintf("sha%d", i)] = githubv4.String(sha)
+		aliasFields[i] = reflect.StructField{
+			Name: alias,
+			Type: fragmentType,
+			Tag:  reflect.StructTag(fmt.Sprintf("graphql:\"%s: object(expression: $sha%d)\"", strings.ToLower(alias), i)),
+		}
+	}
+	queryType := reflect.StructOf([]reflect.StructField{
+		{
+			Name: "Repository",
+			Type: reflect.StructOf(aliasFields),
+			Tag:  `graphql:"repository(name: $name, owner: $owner)"`,
+		},
+	})
+
+	queryPtr := reflect.New(queryType)
+	if err := githubClient.Query(context.Background(), queryPtr.Interface(), variables); err != nil {
+		return nil, fmt.Errorf("batch resolving %d commits: %s", len(shas), err)
+	}
+
+	repository := queryPtr.Elem().FieldByName("Repository")
+	result := make(map[string]*commitPRInfo, len(shas))
+	for i, sha := range shas {
+		fragment := repository.FieldByName(fmt.Sprintf("C%d", i)).Interface().(commitObjectFragment)
+		edges := fragment.Commit.AssociatedPullRequests.Edges
+		if len(edges) == 0 {
+			return nil, fmt.Errorf("could not determine pull request for commit %s", sha)
+		}
+		result[sha] = &commitPRInfo{
+			Number:      int(edges[0].Node.Number),
+			Title:       string(edges[0].Node.Title),
+			AuthorName:  string(fragment.Commit.Author.Name),
+			AuthorLogin: string(fragment.Commit.Author.User.Login),
+		}
+	}
+	return result, nil
+}
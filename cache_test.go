@@ -0,0 +1,111 @@
+package changelog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBatchSHAs(t *testing.T) {
+	cases := []struct {
+		name    string
+		shas    []string
+		size    int
+		batches [][]string
+	}{
+		{"empty", nil, 2, nil},
+		{"exact multiple", []string{"a", "b", "c", "d"}, 2, [][]string{{"a", "b"}, {"c", "d"}}},
+		{"remainder", []string{"a", "b", "c"}, 2, [][]string{{"a", "b"}, {"c"}}},
+		{"smaller than batch size", []string{"a"}, 100, [][]string{{"a"}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := batchSHAs(tc.shas, tc.size)
+			if len(got) != len(tc.batches) {
+				t.Fatalf("got %d batches, want %d: %v", len(got), len(tc.batches), got)
+			}
+			for i := range got {
+				if !equalStrings(got[i], tc.batches[i]) {
+					t.Errorf("batch %d = %v, want %v", i, got[i], tc.batches[i])
+				}
+			}
+		})
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPRInfoCacheEntry_Fresh(t *testing.T) {
+	cases := []struct {
+		name     string
+		cachedAt time.Time
+		ttl      time.Duration
+		want     bool
+	}{
+		{"zero TTL never expires", time.Now().Add(-999 * time.Hour), 0, true},
+		{"within TTL", time.Now().Add(-time.Minute), time.Hour, true},
+		{"past TTL", time.Now().Add(-2 * time.Hour), time.Hour, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := prInfoCacheEntry{CachedAt: tc.cachedAt}
+			if got := e.fresh(tc.ttl); got != tc.want {
+				t.Errorf("fresh(%s) = %v, want %v", tc.ttl, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCachingResolver_LoadSaveRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-changelog-cache-test")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := &cachingResolver{CacheDir: dir}
+
+	cf, err := c.load("hashicorp", "go-changelog")
+	if err != nil {
+		t.Fatalf("load (missing file): %s", err)
+	}
+	if len(cf.Entries) != 0 {
+		t.Fatalf("expected empty cache for missing file, got %+v", cf.Entries)
+	}
+
+	cf.Entries["abc123"] = prInfoCacheEntry{
+		Info:     commitPRInfo{Number: 42, Title: "some PR"},
+		CachedAt: time.Now(),
+	}
+	if err := c.save("hashicorp", "go-changelog", cf); err != nil {
+		t.Fatalf("save: %s", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "hashicorp-go-changelog.json")); err != nil {
+		t.Fatalf("expected cache file to exist: %s", err)
+	}
+
+	reloaded, err := c.load("hashicorp", "go-changelog")
+	if err != nil {
+		t.Fatalf("load (existing file): %s", err)
+	}
+	entry, ok := reloaded.Entries["abc123"]
+	if !ok {
+		t.Fatalf("expected entry for abc123, got %+v", reloaded.Entries)
+	}
+	if entry.Info.Number != 42 || entry.Info.Title != "some PR" {
+		t.Errorf("got entry %+v, want Number=42 Title=%q", entry.Info, "some PR")
+	}
+}
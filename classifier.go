@@ -0,0 +1,97 @@
+package changelog
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// UnclassifiedType is the Note.Type used when an entry has no explicit
+// release-note block and its commit subject (and PR title) match none of a
+// Classifier's rules.
+const UnclassifiedType = "uncategorized"
+
+// classifierRule pairs a note type with the compiled regex used to detect it
+// in a commit subject or PR title.
+type classifierRule struct {
+	Type  string
+	Regex *regexp.Regexp
+}
+
+// Classifier infers a Note's type from the text of the commit (and, when
+// available, the PR) associated with an Entry that has no explicit
+// release-note block. Rules are tried in order and the first match wins.
+type Classifier struct {
+	rules []classifierRule
+}
+
+// defaultClassifierConfig mirrors the keyword conventions used by tools like
+// Hugo's gitInfosToChangeLog: conventional-commit-ish keywords, matched
+// anywhere in the text, map to note types.
+var defaultClassifierConfig = map[string]string{
+	"breaking-change": `BREAKING CHANGE`,
+	"bug":             `(?i)\b(fix|fixes|fixed)\b`,
+	"deprecation":     `(?i)\bdeprecate[sd]?\b`,
+	"docs":            `(?i)\bdocs?\b`,
+	"enhancement":     `(?i)\b(feat|add[s]?)\b`,
+}
+
+// DefaultClassifier returns a Classifier built from the built-in keyword map.
+func DefaultClassifier() *Classifier {
+	c, err := classifierFromConfig(defaultClassifierConfig)
+	if err != nil {
+		// The built-in config is always valid; a failure here is a bug.
+		panic(err)
+	}
+	return c
+}
+
+// LoadClassifierConfig reads a YAML or JSON file at path mapping note type
+// names to regular expressions and returns the Classifier built from it.
+// JSON is valid YAML, so a single unmarshaler handles both formats.
+func LoadClassifierConfig(path string) (*Classifier, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading classifier config %q: %s", path, err)
+	}
+	var config map[string]string
+	if err := yaml.Unmarshal(contents, &config); err != nil {
+		return nil, fmt.Errorf("parsing classifier config %q: %s", path, err)
+	}
+	return classifierFromConfig(config)
+}
+
+func classifierFromConfig(config map[string]string) (*Classifier, error) {
+	// config is a map, whose iteration order is randomized on every range,
+	// but Classifier's doc comment promises that the first matching rule wins.
+	// Sort by note type so that promise holds across runs.
+	noteTypes := make([]string, 0, len(config))
+	for noteType := range config {
+		noteTypes = append(noteTypes, noteType)
+	}
+	sort.Strings(noteTypes)
+
+	rules := make([]classifierRule, 0, len(config))
+	for _, noteType := range noteTypes {
+		re, err := regexp.Compile(config[noteType])
+		if err != nil {
+			return nil, fmt.Errorf("compiling regex for type %q: %s", noteType, err)
+		}
+		rules = append(rules, classifierRule{Type: noteType, Regex: re})
+	}
+	return &Classifier{rules: rules}, nil
+}
+
+// Classify returns the note type whose regex matches entry's commit subject
+// or PR title, or UnclassifiedType if none match.
+func (c *Classifier) Classify(entry Entry) string {
+	for _, rule := range c.rules {
+		if rule.Regex.MatchString(entry.CommitSubject) || rule.Regex.MatchString(entry.PRTitle) {
+			return rule.Type
+		}
+	}
+	return UnclassifiedType
+}
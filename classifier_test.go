@@ -0,0 +1,49 @@
+package changelog
+
+import "testing"
+
+func TestClassifierFromConfig_DeterministicPriority(t *testing.T) {
+	// Both rules match any input, so which one wins depends entirely on
+	// rule order. Run it several times since the bug this guards against
+	// only shows up with map iteration, which is randomized on every range,
+	// so a single call can pass by chance.
+	config := map[string]string{
+		"zzz-type": `.*`,
+		"aaa-type": `.*`,
+	}
+	for i := 0; i < 5; i++ {
+		c, err := classifierFromConfig(config)
+		if err != nil {
+			t.Fatalf("classifierFromConfig: %s", err)
+		}
+		got := c.Classify(Entry{CommitSubject: "anything"})
+		if got != "aaa-type" {
+			t.Fatalf("run %d: got %q, want %q (rules must be sorted by type name)", i, got, "aaa-type")
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	c := DefaultClassifier()
+	cases := []struct {
+		name  string
+		entry Entry
+		want  string
+	}{
+		{"bug keyword", Entry{CommitSubject: "fix: nil pointer in parser"}, "bug"},
+		{"deprecation keyword", Entry{CommitSubject: "deprecate the old -legacy flag"}, "deprecation"},
+		{"enhancement keyword", Entry{CommitSubject: "feat: add --dry-run flag"}, "enhancement"},
+		{"breaking change", Entry{CommitSubject: "BREAKING CHANGE: rename -x to -y"}, "breaking-change"},
+		{"docs keyword", Entry{CommitSubject: "docs: update README"}, "docs"},
+		{"no match", Entry{CommitSubject: "tidy up imports"}, UnclassifiedType},
+		{"falls back to PR title", Entry{CommitSubject: "misc", PRTitle: "fix: flaky test"}, "bug"},
+		{"bug beats docs when both match", Entry{CommitSubject: "fix docs typo"}, "bug"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := c.Classify(tc.entry); got != tc.want {
+				t.Errorf("Classify(%+v) = %q, want %q", tc.entry, got, tc.want)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"sort"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/hashicorp/go-changelog"
 	"github.com/shurcooL/githubv4"
@@ -21,13 +22,23 @@ const (
 	githubTokenEnvVar       = "GITHUB_TOKEN"
 )
 
+// defaultCacheDir returns $XDG_CACHE_HOME/go-changelog, falling back to
+// $HOME/.cache/go-changelog if XDG_CACHE_HOME isn't set.
+func defaultCacheDir() string {
+	cacheHome := os.Getenv("XDG_CACHE_HOME")
+	if cacheHome == "" {
+		cacheHome = filepath.Join(os.Getenv("HOME"), ".cache")
+	}
+	return filepath.Join(cacheHome, "go-changelog")
+}
+
 func main() {
 	pwd, err := os.Getwd()
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
-	var lastRelease, thisRelease, repoDir, entriesDir, noteTmpl, changelogTmpl, filenameFormat, repo string
+	var lastRelease, thisRelease, repoDir, entriesDir, noteTmpl, changelogTmpl, filenameFormat, repo, classifierConfig string
 	flag.StringVar(&lastRelease, "last-release", "", "a git ref to the last commit in the previous release")
 	flag.StringVar(&thisRelease, "this-release", "", "a git ref to the last commit to include in this release")
 	flag.StringVar(&repoDir, "git-dir", pwd, "the directory of the git repo being released")
@@ -37,6 +48,21 @@ func main() {
 	flag.StringVar(&filenameFormat, "filename-format", "pr-number", "the changelog entry filename format: 'pr-number' or 'timestamp'. If set to 'timestamp',"+
 		" the env var GITHUB_TOKEN must be set to a personal access token with 'repo' scope so that PR numbers can be retrieved from the GitHub API")
 	flag.StringVar(&repo, "repo", "", "name of the repo, e.g. 'hashicorp/consul'. Must be set if -filename-format=timestamp")
+	flag.StringVar(&classifierConfig, "classifier-config", "", "path to a YAML or JSON file mapping note types to regexes used to classify"+
+		" entries that have no explicit release-note block, by matching against the entry's commit subject (and PR title, if -filename-format=timestamp)."+
+		" If unset, a built-in set of conventional-commit-style keywords is used")
+	var withStats bool
+	flag.BoolVar(&withStats, "with-stats", false, "resolve commit and contributor statistics for the release and expose them to the changelog"+
+		" template as .Stats. Only supported if -filename-format=timestamp")
+	var cacheDir string
+	var noCache bool
+	var cacheTTL time.Duration
+	flag.StringVar(&cacheDir, "cache-dir", defaultCacheDir(), "directory to cache GitHub PR lookups in, keyed by commit sha. Only used if -filename-format=timestamp")
+	flag.BoolVar(&noCache, "no-cache", false, "don't use the on-disk PR lookup cache, even if -filename-format=timestamp")
+	flag.DurationVar(&cacheTTL, "cache-ttl", 0, "how long a cached PR lookup is trusted before it's re-resolved, e.g. '720h'. Zero means cached entries never expire")
+	var local bool
+	flag.BoolVar(&local, "local", false, "treat -git-dir as a local .git directory and read entries directly out of its object database instead of cloning"+
+		" it into memory. Only supported if -filename-format=pr-number")
 	flag.Parse()
 
 	if lastRelease == "" {
@@ -113,6 +139,16 @@ func main() {
 		os.Exit(1)
 	}
 
+	if withStats && filenameFormat != filenameFormatTimestamp {
+		fmt.Fprintf(os.Stderr, "-with-stats requires -filename-format=%s\n", filenameFormatTimestamp)
+		os.Exit(1)
+	}
+
+	if local && filenameFormat != filenameFormatPRNumber {
+		fmt.Fprintf(os.Stderr, "-local requires -filename-format=%s\n", filenameFormatPRNumber)
+		os.Exit(1)
+	}
+
 	tmpl := template.New(filepath.Base(changelogTmpl)).Funcs(template.FuncMap{
 		"sort": func(in []changelog.Note) []changelog.Note {
 			sort.Slice(in, changelog.SortNotes(in))
@@ -145,24 +181,54 @@ func main() {
 		os.Exit(1)
 	}
 
+	var resolver changelog.CommitPRResolver
+	switch {
+	case filenameFormat != filenameFormatTimestamp:
+	case noCache:
+		// Still batch lookups even without a cache; -no-cache should only
+		// skip persisting to disk, not the batching that makes large
+		// releases tractable.
+		resolver = changelog.NewBatchingResolver()
+	default:
+		resolver = changelog.NewCommitPRResolver(cacheDir, cacheTTL)
+	}
+
 	var entries []changelog.Entry
-	switch filenameFormat {
-	case filenameFormatTimestamp:
-		entries, err = changelog.DiffFilenameFmtTimestamp(repoDir, lastRelease, thisRelease, entriesDir, repoOwner, repoName, githubClient)
-	case filenameFormatPRNumber:
+	var stats *changelog.ReleaseStats
+	switch {
+	case withStats:
+		entries, stats, err = changelog.DiffFilenameFmtTimestampWithStats(repoDir, lastRelease, thisRelease, entriesDir, repoOwner, repoName, githubClient, resolver)
+	case filenameFormat == filenameFormatTimestamp:
+		entries, err = changelog.DiffFilenameFmtTimestampWithResolver(repoDir, lastRelease, thisRelease, entriesDir, repoOwner, repoName, githubClient, resolver)
+	case local:
+		var differ *changelog.Differ
+		differ, err = changelog.Open(repoDir, changelog.DiffOptions{Clone: false})
+		if err == nil {
+			entries, err = differ.Diff(lastRelease, thisRelease, entriesDir)
+		}
+	case filenameFormat == filenameFormatPRNumber:
 		entries, err = changelog.Diff(repoDir, lastRelease, thisRelease, entriesDir)
 	}
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+	classifier := changelog.DefaultClassifier()
+	if classifierConfig != "" {
+		classifier, err = changelog.LoadClassifierConfig(classifierConfig)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
 	var notes []changelog.Note
 	notesByType := map[string][]changelog.Note{}
 	for _, entry := range entries {
 		if strings.HasSuffix(entry.Issue, ".txt") {
 			entry.Issue = strings.TrimSuffix(entry.Issue, ".txt")
 		}
-		notes = append(notes, changelog.NotesFromEntry(entry)...)
+		notes = append(notes, changelog.NotesFromEntry(entry, classifier)...)
 	}
 	for _, note := range notes {
 		notesByType[note.Type] = append(notesByType[note.Type], note)
@@ -174,10 +240,12 @@ func main() {
 	type renderData struct {
 		Notes       []changelog.Note
 		NotesByType map[string][]changelog.Note
+		Stats       *changelog.ReleaseStats
 	}
 	err = tmpl.Execute(os.Stdout, renderData{
 		Notes:       notes,
 		NotesByType: notesByType,
+		Stats:       stats,
 	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error executing templates: %s\n", err)
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/v32/github"
+	"github.com/hashicorp/go-changelog"
+	"golang.org/x/oauth2"
+)
+
+const githubTokenEnvVar = "GITHUB_TOKEN"
+
+func main() {
+	var repo, tag, changelogFile, targetCommitish string
+	var draft, prerelease, updateIfExists bool
+	flag.StringVar(&repo, "repo", "", "name of the repo to publish the release to, e.g. 'hashicorp/consul'")
+	flag.StringVar(&tag, "tag", "", "the tag to create (or update) the release for, e.g. 'v1.2.3'")
+	flag.StringVar(&changelogFile, "changelog-file", "", "path to the rendered changelog to use as the release body. If unset, read from stdin")
+	flag.StringVar(&targetCommitish, "target-commitish", "", "the commitish value the tag is created from, if the tag doesn't already exist. Defaults to the repository's default branch")
+	flag.BoolVar(&draft, "draft", false, "create the release as a draft")
+	flag.BoolVar(&prerelease, "prerelease", false, "mark the release as a prerelease")
+	flag.BoolVar(&updateIfExists, "update-if-exists", false, "if a release for -tag already exists, update its body instead of erroring")
+	flag.Parse()
+
+	if repo == "" {
+		fmt.Fprintln(os.Stderr, "Must specify -repo.")
+		fmt.Fprintln(os.Stderr, "")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if tag == "" {
+		fmt.Fprintln(os.Stderr, "Must specify -tag.")
+		fmt.Fprintln(os.Stderr, "")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	repoSplit := strings.Split(repo, "/")
+	if len(repoSplit) != 2 {
+		fmt.Fprintf(os.Stderr, "-repo=%s is invalid: must be set as 'repoOwner/repoName', e.g. 'hashicorp/consul'\n", repo)
+		os.Exit(1)
+	}
+	repoOwner, repoName := repoSplit[0], repoSplit[1]
+
+	githubToken := os.Getenv(githubTokenEnvVar)
+	if githubToken == "" {
+		fmt.Fprintf(os.Stderr, "Env var %s must be set to a GitHub token with 'repo' scope\n", githubTokenEnvVar)
+		os.Exit(1)
+	}
+
+	var body []byte
+	var err error
+	if changelogFile != "" {
+		body, err = ioutil.ReadFile(changelogFile)
+	} else {
+		body, err = ioutil.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	tokenSrc := oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: githubToken},
+	)
+	httpClient := oauth2.NewClient(context.Background(), tokenSrc)
+	githubClient := github.NewClient(httpClient)
+
+	publisher := changelog.NewPublisher(githubClient)
+	err = publisher.Publish(context.Background(), changelog.PublishOptions{
+		RepoOwner:       repoOwner,
+		RepoName:        repoName,
+		Tag:             tag,
+		Body:            string(body),
+		Draft:           draft,
+		Prerelease:      prerelease,
+		TargetCommitish: targetCommitish,
+		UpdateIfExists:  updateIfExists,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
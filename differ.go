@@ -0,0 +1,149 @@
+package changelog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/src-d/go-billy.v4/memfs"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/storage/memory"
+)
+
+// DiffOptions configures how Open resolves repoDir.
+type DiffOptions struct {
+	// Clone, when true, clones repoDir (expected to be a remote URL) into
+	// an in-memory repository, the same way the package-level Diff function
+	// always has. When false (the default), repoDir must be a local path to
+	// a repository's working tree root or its .git directory, which is opened
+	// in place with go-git's PlainOpen; parent directories are not searched. Either way, Differ reads entries straight out of the
+	// object database at the target refs -- no worktree checkout.
+	Clone bool
+}
+
+// Differ reads changelog entries out of a git repository's object database
+// without checking out a worktree, so it's cheap to reuse across many
+// ref pairs against the same repository. It's the single implementation of
+// "walk entriesDir at a ref and find the latest commit per file" -- diffReal
+// builds its PR-number and contributor resolution on top of it rather than
+// duplicating the walk.
+type Differ struct {
+	repo *git.Repository
+}
+
+// Open returns a Differ for the repository at repoDir, per opts.
+func Open(repoDir string, opts DiffOptions) (*Differ, error) {
+	if opts.Clone {
+		r, err := git.Clone(memory.NewStorage(), memfs.New(), &git.CloneOptions{
+			URL: repoDir,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cloning %q: %s", repoDir, err)
+		}
+		return &Differ{repo: r}, nil
+	}
+	r, err := git.PlainOpen(repoDir)
+	if err != nil {
+		return nil, fmt.Errorf("opening %q as a local git repository: %s", repoDir, err)
+	}
+	return &Differ{repo: r}, nil
+}
+
+// Diff returns the entries added under entriesDir between ref1 and ref2 (or
+// all entries present at ref2 if ref1 is "-").
+func (d *Differ) Diff(ref1, ref2, entriesDir string) ([]Entry, error) {
+	files, err := d.filesBetweenRefs(ref1, ref2, entriesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(files))
+	for filename, ef := range files {
+		entries = append(entries, Entry{
+			Issue:         strings.TrimSuffix(filename, ".txt"),
+			Body:          string(ef.Contents),
+			CommitSubject: ef.CommitSubject,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Issue < entries[j].Issue
+	})
+	return entries, nil
+}
+
+// filesBetweenRefs returns the entry files added under entriesDir between
+// ref1 and ref2 (or all files present at ref2 if ref1 is "-"), keyed by
+// filename.
+func (d *Differ) filesBetweenRefs(ref1, ref2, entriesDir string) (map[string]entryFile, error) {
+	afterFiles, err := d.entriesAtRef(ref2, entriesDir)
+	if err != nil {
+		return nil, err
+	}
+	if ref1 != "-" {
+		beforeFiles, err := d.entriesAtRef(ref1, entriesDir)
+		if err != nil {
+			return nil, err
+		}
+		for name := range beforeFiles {
+			delete(afterFiles, name)
+		}
+	}
+	return afterFiles, nil
+}
+
+func (d *Differ) entriesAtRef(ref, entriesDir string) (map[string]entryFile, error) {
+	hash, err := d.repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return nil, fmt.Errorf("resolving %q: %s", ref, err)
+	}
+	commit, err := d.repo.CommitObject(*hash)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	dirTree, err := tree.Tree(entriesDir)
+	if err != nil {
+		return nil, fmt.Errorf("reading %q at %q: %s", entriesDir, ref, err)
+	}
+
+	files := make(map[string]entryFile)
+	err = dirTree.Files().ForEach(func(f *object.File) error {
+		contents, err := f.Contents()
+		if err != nil {
+			return err
+		}
+		rootRelFileName := entriesDir + "/" + f.Name
+		latestCommit, err := d.latestCommitForPath(*hash, rootRelFileName)
+		if err != nil {
+			return err
+		}
+		files[f.Name] = entryFile{
+			Contents:      []byte(contents),
+			CommitHash:    latestCommit.Hash.String(),
+			CommitSubject: strings.SplitN(latestCommit.Message, "\n", 2)[0],
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func (d *Differ) latestCommitForPath(from plumbing.Hash, path string) (*object.Commit, error) {
+	commitIter, err := d.repo.Log(&git.LogOptions{From: from, FileName: &path})
+	if err != nil {
+		return nil, err
+	}
+	defer commitIter.Close()
+	latestCommit, err := commitIter.Next()
+	if err != nil {
+		return nil, fmt.Errorf("found no commits for %q: %s", path, err)
+	}
+	return latestCommit, nil
+}
@@ -0,0 +1,84 @@
+package changelog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+func TestDiffer_Diff(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-changelog-differ-test")
+	if err != nil {
+		t.Fatalf("TempDir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatalf("PlainInit: %s", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("Worktree: %s", err)
+	}
+
+	const entriesDir = ".changelog"
+	if err := os.MkdirAll(filepath.Join(dir, entriesDir), 0755); err != nil {
+		t.Fatalf("MkdirAll: %s", err)
+	}
+
+	writeAndCommit := func(filename, contents, message string) string {
+		path := filepath.Join(dir, entriesDir, filename)
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatalf("WriteFile: %s", err)
+		}
+		if _, err := wt.Add(filepath.Join(entriesDir, filename)); err != nil {
+			t.Fatalf("Add: %s", err)
+		}
+		hash, err := wt.Commit(message, &git.CommitOptions{
+			Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+		})
+		if err != nil {
+			t.Fatalf("Commit: %s", err)
+		}
+		return hash.String()
+	}
+
+	base := writeAndCommit("100.txt", "first entry", "add first entry")
+	head := writeAndCommit("200.txt", "second entry", "add second entry")
+
+	d, err := Open(dir, DiffOptions{Clone: false})
+	if err != nil {
+		t.Fatalf("Open: %s", err)
+	}
+
+	entries, err := d.Diff(base, head, entriesDir)
+	if err != nil {
+		t.Fatalf("Diff: %s", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1: %+v", len(entries), entries)
+	}
+	if entries[0].Issue != "200" {
+		t.Errorf("got issue %q, want %q", entries[0].Issue, "200")
+	}
+	if entries[0].Body != "second entry" {
+		t.Errorf("got body %q, want %q", entries[0].Body, "second entry")
+	}
+	if entries[0].CommitSubject != "add second entry" {
+		t.Errorf("got commit subject %q, want %q", entries[0].CommitSubject, "add second entry")
+	}
+
+	all, err := d.Diff("-", head, entriesDir)
+	if err != nil {
+		t.Fatalf("Diff with \"-\": %s", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("got %d entries, want 2: %+v", len(all), all)
+	}
+}
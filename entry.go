@@ -3,142 +3,180 @@ package changelog
 import (
 	"context"
 	"fmt"
-	"io/ioutil"
-	"path/filepath"
 	"sort"
-	"strings"
 
 	"github.com/shurcooL/githubv4"
-	"gopkg.in/src-d/go-billy.v4/memfs"
-	"gopkg.in/src-d/go-git.v4"
-	"gopkg.in/src-d/go-git.v4/plumbing"
-	"gopkg.in/src-d/go-git.v4/storage/memory"
 )
 
 type Entry struct {
 	Issue string
 	Body  string
+
+	// CommitSubject is the subject line of the commit that last touched this
+	// entry's file. It's used by NotesFromEntry to classify entries that
+	// have no explicit release-note block.
+	CommitSubject string
+
+	// PRTitle is the title of the pull request associated with this entry's
+	// commit. It's only populated when filename-format=timestamp, since
+	// that's the only mode that already looks up the PR to find its number.
+	PRTitle string
 }
 
 type entryFile struct {
-	Contents   []byte
-	CommitHash string
+	Contents      []byte
+	CommitHash    string
+	CommitSubject string
 }
 
+// Diff returns the entries added under entriesDir between ref1 and ref2 (or
+// all entries present at ref2 if ref1 is "-"), cloning repo into memory
+// first. It's a convenience wrapper around Open and Differ.Diff for
+// filename-format=pr-number, where an entry's Issue is just its filename.
 func Diff(repo, ref1, ref2, entriesDir string) ([]Entry, error) {
-	return diffReal(repo, ref1, ref2, entriesDir, "", "", false, nil)
+	d, err := Open(repo, DiffOptions{Clone: true})
+	if err != nil {
+		return nil, err
+	}
+	return d.Diff(ref1, ref2, entriesDir)
 }
+
+// DiffFilenameFmtTimestamp resolves each entry's PR number with one GraphQL
+// query per commit. Pass a CommitPRResolver to DiffFilenameFmtTimestampWithResolver
+// instead for large releases, to batch and cache those lookups.
 func DiffFilenameFmtTimestamp(repoDir, ref1, ref2, entriesDir, repoOwner, repoName string, githubClient *githubv4.Client) ([]Entry, error) {
-	return diffReal(repoDir, ref1, ref2, entriesDir, repoOwner, repoName, true, githubClient)
+	entries, _, err := diffReal(repoDir, ref1, ref2, entriesDir, repoOwner, repoName, false, githubClient, nil)
+	return entries, err
 }
 
-func diffReal(repoDir, ref1, ref2, entriesDir, repoOwner, repoName string, timestampFmt bool, githubClient *githubv4.Client) ([]Entry, error) {
-	r, err := git.Clone(memory.NewStorage(), memfs.New(), &git.CloneOptions{
-		URL: repoDir,
-	})
+// DiffFilenameFmtTimestampWithResolver behaves like DiffFilenameFmtTimestamp
+// but resolves PR numbers via resolver instead of issuing one query per
+// commit.
+func DiffFilenameFmtTimestampWithResolver(repoDir, ref1, ref2, entriesDir, repoOwner, repoName string, githubClient *githubv4.Client, resolver CommitPRResolver) ([]Entry, error) {
+	entries, _, err := diffReal(repoDir, ref1, ref2, entriesDir, repoOwner, repoName, false, githubClient, resolver)
+	return entries, err
+}
+
+// DiffFilenameFmtTimestampWithStats behaves like
+// DiffFilenameFmtTimestampWithResolver (or DiffFilenameFmtTimestamp, if
+// resolver is nil) but additionally returns aggregate ReleaseStats built
+// from the GitHub login of each entry's commit author.
+func DiffFilenameFmtTimestampWithStats(repoDir, ref1, ref2, entriesDir, repoOwner, repoName string, githubClient *githubv4.Client, resolver CommitPRResolver) ([]Entry, *ReleaseStats, error) {
+	return diffReal(repoDir, ref1, ref2, entriesDir, repoOwner, repoName, true, githubClient, resolver)
+}
+
+// diffReal resolves the entries between ref1 and ref2 via Differ, then
+// layers PR-number (and, if withStats, contributor) resolution on top. repoDir
+// is cloned into memory the same way the package-level Diff function does;
+// callers that already have a local working tree should use Open and
+// Differ.Diff directly instead.
+func diffReal(repoDir, ref1, ref2, entriesDir, repoOwner, repoName string, withStats bool, githubClient *githubv4.Client, resolver CommitPRResolver) ([]Entry, *ReleaseStats, error) {
+	d, err := Open(repoDir, DiffOptions{Clone: true})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	rev2, err := r.ResolveRevision(plumbing.Revision(ref2))
+	entriesAfter, err := d.filesBetweenRefs(ref1, ref2, entriesDir)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	var rev1 *plumbing.Hash
-	if ref1 != "-" {
-		rev1, err = r.ResolveRevision(plumbing.Revision(ref1))
-		if err != nil {
-			return nil, err
-		}
+
+	entries := make([]Entry, 0, len(entriesAfter))
+	contributorsByLogin := map[string]*Contributor{}
+	prNumbersByLogin := map[string][]int{}
+
+	shas := make([]string, 0, len(entriesAfter))
+	for _, entry := range entriesAfter {
+		shas = append(shas, entry.CommitHash)
 	}
-	wt, err := r.Worktree()
-	if err != nil {
-		return nil, err
+	var prInfoBySHA map[string]*commitPRInfo
+	if resolver != nil {
+		prInfoBySHA, err = resolver.Resolve(shas, repoOwner, repoName, githubClient)
+	} else {
+		prInfoBySHA, err = prInfoForCommits(shas, repoOwner, repoName, githubClient)
 	}
-	err = wt.Checkout(&git.CheckoutOptions{
-		Hash:  *rev2,
-		Force: true,
-	})
-	entriesAfterFI, err := wt.Filesystem.ReadDir(entriesDir)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	entriesAfter := make(map[string]entryFile, len(entriesAfterFI))
-	for _, i := range entriesAfterFI {
-		rootRelFileName := filepath.Join(entriesDir, i.Name())
-		f, err := wt.Filesystem.Open(rootRelFileName)
-		if err != nil {
-			return nil, err
-		}
-		contents, err := ioutil.ReadAll(f)
-		f.Close()
-		if err != nil {
-			return nil, err
-		}
-		iter, err := r.Log(&git.LogOptions{
-			FileName: &rootRelFileName,
-		})
-		if err != nil {
-			return nil, err
-		}
-		latestCommit, err := iter.Next()
-		if err != nil {
-			return nil, fmt.Errorf("found no commits for %q: %s", rootRelFileName, err)
-		} else if latestCommit == nil {
-			return nil, fmt.Errorf("found no commits for %q", rootRelFileName)
-		}
 
-		entriesAfter[i.Name()] = entryFile{
-			Contents:   contents,
-			CommitHash: latestCommit.Hash.String(),
+	for _, entry := range entriesAfter {
+		info, ok := prInfoBySHA[entry.CommitHash]
+		if !ok {
+			return nil, nil, fmt.Errorf("could not determine pull request for commit %s", entry.CommitHash)
 		}
-	}
-	if rev1 != nil {
-		err = wt.Checkout(&git.CheckoutOptions{
-			Hash:  *rev1,
-			Force: true,
-		})
-		entriesBeforeFI, err := wt.Filesystem.ReadDir(entriesDir)
-		if err != nil {
-			return nil, err
-		}
-		for _, i := range entriesBeforeFI {
-			delete(entriesAfter, i.Name())
-		}
-	}
-	entries := make([]Entry, 0, len(entriesAfter))
-
-	for filename, entry := range entriesAfter {
-		var issue string
-		if timestampFmt {
-			var err error
-			issue, err = issueNumForCommit(entry.CommitHash, repoOwner, repoName, githubClient)
-			if err != nil {
-				return nil, err
+		if withStats && info.AuthorLogin != "" {
+			c, ok := contributorsByLogin[info.AuthorLogin]
+			if !ok {
+				c = &Contributor{Name: info.AuthorName, Login: info.AuthorLogin}
+				contributorsByLogin[info.AuthorLogin] = c
 			}
-		} else {
-			issue = strings.TrimSuffix(filename, ".txt")
+			c.PRCount++
+			prNumbersByLogin[info.AuthorLogin] = append(prNumbersByLogin[info.AuthorLogin], info.Number)
 		}
 
 		entries = append(entries, Entry{
-			Issue: issue,
-			Body:  string(entry.Contents),
+			Issue:         fmt.Sprintf("%d", info.Number),
+			Body:          string(entry.Contents),
+			CommitSubject: entry.CommitSubject,
+			PRTitle:       info.Title,
 		})
 	}
 	sort.Slice(entries, func(i, j int) bool {
 		return entries[i].Issue < entries[j].Issue
 	})
-	return entries, nil
+
+	if !withStats {
+		return entries, nil, nil
+	}
+	stats, err := releaseStats(contributorsByLogin, prNumbersByLogin, len(entries), repoOwner, repoName, githubClient)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entries, stats, nil
+}
+
+// commitPRInfo holds the pull request and author details resolved for a
+// single commit.
+type commitPRInfo struct {
+	Number int
+	Title  string
+
+	// AuthorName and AuthorLogin describe the commit's author. AuthorLogin
+	// is empty if the commit's author isn't a GitHub user (e.g. a bot
+	// commit with no linked account).
+	AuthorName  string
+	AuthorLogin string
+}
+
+// prInfoForCommits resolves each of shas with its own GraphQL query. It's the
+// fallback used when diffReal isn't given a CommitPRResolver.
+func prInfoForCommits(shas []string, repoOwner, repoName string, githubClient *githubv4.Client) (map[string]*commitPRInfo, error) {
+	result := make(map[string]*commitPRInfo, len(shas))
+	for _, sha := range shas {
+		info, err := prInfoForCommit(sha, repoOwner, repoName, githubClient)
+		if err != nil {
+			return nil, err
+		}
+		result[sha] = info
+	}
+	return result, nil
 }
 
-func issueNumForCommit(commitHash, repoOwner, repoName string, githubClient *githubv4.Client) (string, error) {
+func prInfoForCommit(commitHash, repoOwner, repoName string, githubClient *githubv4.Client) (*commitPRInfo, error) {
 	var q struct {
 		Repository struct {
 			Commit struct {
 				Commit struct {
+					Author struct {
+						Name githubv4.String
+						User struct {
+							Login githubv4.String
+						}
+					}
 					AssociatedPullRequests struct {
 						Edges []struct {
 							Node struct {
 								Number githubv4.Int
+								Title  githubv4.String
 							}
 						}
 					} `graphql:"associatedPullRequests(first: 1)"`
@@ -153,11 +191,17 @@ func issueNumForCommit(commitHash, repoOwner, repoName string, githubClient *git
 	variables["sha"] = githubv4.String(commitHash)
 	err := githubClient.Query(context.Background(), &q, variables)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	edges := q.Repository.Commit.Commit.AssociatedPullRequests.Edges
 	if len(edges) == 0 {
-		return "", fmt.Errorf("could not determine pull request for commit %s", commitHash)
+		return nil, fmt.Errorf("could not determine pull request for commit %s", commitHash)
 	}
-	return fmt.Sprintf("%d", edges[0].Node.Number), nil
+	author := q.Repository.Commit.Commit.Author
+	return &commitPRInfo{
+		Number:      int(edges[0].Node.Number),
+		Title:       string(edges[0].Node.Title),
+		AuthorName:  string(author.Name),
+		AuthorLogin: string(author.User.Login),
+	}, nil
 }
@@ -0,0 +1,113 @@
+package changelog
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Note is a single changelog entry extracted from an Entry. An Entry's body
+// may contain multiple release-note blocks, each of which becomes its own
+// Note.
+type Note struct {
+	Issue string
+	Type  string
+	Body  string
+
+	// Component, Issues, and Breaking come from the entry's optional YAML
+	// frontmatter and are shared by every Note parsed from the same Entry.
+	Component string
+	Issues    []string
+	Breaking  bool
+}
+
+var noteBlockRegex = regexp.MustCompile("```release-note:([a-z-]+)\\s*\\n([\\s\\S]*?)\\n```")
+
+var frontmatterRegex = regexp.MustCompile(`(?s)\A---\n(.*?)\n---\n?`)
+
+// entryFrontmatter is the optional YAML header an entry file may start with,
+// e.g.:
+//
+//	---
+//	issues: [123, 456]
+//	component: agent
+//	breaking: true
+//	---
+type entryFrontmatter struct {
+	Issues    []int  `yaml:"issues"`
+	Component string `yaml:"component"`
+	Breaking  bool   `yaml:"breaking"`
+}
+
+// splitFrontmatter strips a leading YAML frontmatter block from body, if
+// present, and returns it alongside the remaining body.
+func splitFrontmatter(body string) (entryFrontmatter, string) {
+	m := frontmatterRegex.FindStringSubmatch(body)
+	if m == nil {
+		return entryFrontmatter{}, body
+	}
+	var fm entryFrontmatter
+	// A malformed frontmatter block is treated as if it weren't there,
+	// rather than failing the whole entry.
+	if err := yaml.Unmarshal([]byte(m[1]), &fm); err != nil {
+		return entryFrontmatter{}, body
+	}
+	return fm, body[len(m[0]):]
+}
+
+// NotesFromEntry parses entry.Body for an optional YAML frontmatter block
+// followed by one or more ```release-note:<type>``` blocks, and returns a
+// Note for each release-note block found. If the body contains no explicit
+// release-note block, classifier is used to infer a single Note's type from
+// the entry's commit subject (and PR title, if set). If classifier is nil,
+// DefaultClassifier() is used.
+func NotesFromEntry(entry Entry, classifier *Classifier) []Note {
+	fm, body := splitFrontmatter(entry.Body)
+	var issues []string
+	for _, issue := range fm.Issues {
+		issues = append(issues, strconv.Itoa(issue))
+	}
+
+	matches := noteBlockRegex.FindAllStringSubmatch(body, -1)
+	if len(matches) == 0 {
+		if classifier == nil {
+			classifier = DefaultClassifier()
+		}
+		return []Note{
+			{
+				Issue:     entry.Issue,
+				Type:      classifier.Classify(entry),
+				Body:      strings.TrimSpace(body),
+				Component: fm.Component,
+				Issues:    issues,
+				Breaking:  fm.Breaking,
+			},
+		}
+	}
+
+	notes := make([]Note, 0, len(matches))
+	for _, m := range matches {
+		notes = append(notes, Note{
+			Issue:     entry.Issue,
+			Type:      m[1],
+			Body:      strings.TrimSpace(m[2]),
+			Component: fm.Component,
+			Issues:    issues,
+			Breaking:  fm.Breaking,
+		})
+	}
+	return notes
+}
+
+// SortNotes returns a less function for use with sort.Slice that orders
+// notes by type and then by issue.
+func SortNotes(notes []Note) func(i, j int) bool {
+	return func(i, j int) bool {
+		if notes[i].Type != notes[j].Type {
+			return notes[i].Type < notes[j].Type
+		}
+		return notes[i].Issue < notes[j].Issue
+	}
+}
@@ -0,0 +1,94 @@
+package changelog
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNotesFromEntry_SingleReleaseNoteBlock(t *testing.T) {
+	entry := Entry{
+		Issue: "123",
+		Body:  "```release-note:bug\nFix the thing.\n```",
+	}
+	notes := NotesFromEntry(entry, nil)
+	want := []Note{
+		{Issue: "123", Type: "bug", Body: "Fix the thing."},
+	}
+	if !reflect.DeepEqual(notes, want) {
+		t.Errorf("got %+v, want %+v", notes, want)
+	}
+}
+
+func TestNotesFromEntry_MultipleReleaseNoteBlocks(t *testing.T) {
+	entry := Entry{
+		Issue: "123",
+		Body: "```release-note:bug\nFix the thing.\n```\n" +
+			"```release-note:enhancement\nAdd the other thing.\n```",
+	}
+	notes := NotesFromEntry(entry, nil)
+	want := []Note{
+		{Issue: "123", Type: "bug", Body: "Fix the thing."},
+		{Issue: "123", Type: "enhancement", Body: "Add the other thing."},
+	}
+	if !reflect.DeepEqual(notes, want) {
+		t.Errorf("got %+v, want %+v", notes, want)
+	}
+}
+
+func TestNotesFromEntry_Frontmatter(t *testing.T) {
+	entry := Entry{
+		Issue: "123",
+		Body: "---\n" +
+			"issues: [123, 456]\n" +
+			"component: agent\n" +
+			"breaking: true\n" +
+			"---\n" +
+			"```release-note:bug\nFix the thing.\n```",
+	}
+	notes := NotesFromEntry(entry, nil)
+	want := []Note{
+		{
+			Issue:     "123",
+			Type:      "bug",
+			Body:      "Fix the thing.",
+			Component: "agent",
+			Issues:    []string{"123", "456"},
+			Breaking:  true,
+		},
+	}
+	if !reflect.DeepEqual(notes, want) {
+		t.Errorf("got %+v, want %+v", notes, want)
+	}
+}
+
+func TestNotesFromEntry_NoBlockFallsBackToClassifier(t *testing.T) {
+	entry := Entry{
+		Issue:         "123",
+		Body:          "just some prose, no release-note block",
+		CommitSubject: "fix: nil pointer in parser",
+	}
+	notes := NotesFromEntry(entry, DefaultClassifier())
+	want := []Note{
+		{Issue: "123", Type: "bug", Body: "just some prose, no release-note block"},
+	}
+	if !reflect.DeepEqual(notes, want) {
+		t.Errorf("got %+v, want %+v", notes, want)
+	}
+}
+
+func TestNotesFromEntry_MalformedFrontmatterIgnored(t *testing.T) {
+	entry := Entry{
+		Issue: "123",
+		Body: "---\n" +
+			"issues: [this is not valid yaml for an int list\n" +
+			"---\n" +
+			"```release-note:bug\nFix the thing.\n```",
+	}
+	notes := NotesFromEntry(entry, nil)
+	if len(notes) != 1 {
+		t.Fatalf("got %d notes, want 1: %+v", len(notes), notes)
+	}
+	if notes[0].Component != "" || notes[0].Breaking {
+		t.Errorf("expected malformed frontmatter to be ignored, got %+v", notes[0])
+	}
+}
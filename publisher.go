@@ -0,0 +1,97 @@
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/go-github/v32/github"
+)
+
+// Publisher creates or updates a GitHub Release from rendered changelog
+// output. It uses the REST v3 API's Repositories service rather than the v4
+// GraphQL client diffReal uses, since GitHub's GraphQL API has no
+// release-creation or release-update mutations.
+type Publisher struct {
+	Client *github.Client
+}
+
+// NewPublisher returns a Publisher that talks to GitHub using client.
+func NewPublisher(client *github.Client) *Publisher {
+	return &Publisher{Client: client}
+}
+
+// PublishOptions configures a single call to Publisher.Publish.
+type PublishOptions struct {
+	RepoOwner string
+	RepoName  string
+
+	// Tag is the release's tag name, e.g. "v1.2.3".
+	Tag string
+
+	// Body is the rendered changelog to use as the release's description.
+	Body string
+
+	Draft           bool
+	Prerelease      bool
+	TargetCommitish string
+
+	// UpdateIfExists, when true, patches the body of an existing release for
+	// Tag instead of returning an error.
+	UpdateIfExists bool
+}
+
+// Publish creates a GitHub Release for opts.Tag, or updates the existing one
+// if opts.UpdateIfExists is set and a release for that tag already exists.
+func (p *Publisher) Publish(ctx context.Context, opts PublishOptions) error {
+	existing, err := p.releaseForTag(ctx, opts.RepoOwner, opts.RepoName, opts.Tag)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		if !opts.UpdateIfExists {
+			return fmt.Errorf("release for tag %q already exists in %s/%s", opts.Tag, opts.RepoOwner, opts.RepoName)
+		}
+		return p.updateRelease(ctx, opts.RepoOwner, opts.RepoName, existing.GetID(), opts)
+	}
+	return p.createRelease(ctx, opts.RepoOwner, opts.RepoName, opts)
+}
+
+// releaseForTag returns the existing release for tag, or nil if no release
+// has been created for it yet.
+func (p *Publisher) releaseForTag(ctx context.Context, owner, name, tag string) (*github.RepositoryRelease, error) {
+	release, resp, err := p.Client.Repositories.GetReleaseByTag(ctx, owner, name, tag)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("looking up release for tag %q: %s", tag, err)
+	}
+	return release, nil
+}
+
+func (p *Publisher) createRelease(ctx context.Context, owner, name string, opts PublishOptions) error {
+	release := &github.RepositoryRelease{
+		TagName:    github.String(opts.Tag),
+		Body:       github.String(opts.Body),
+		Draft:      github.Bool(opts.Draft),
+		Prerelease: github.Bool(opts.Prerelease),
+	}
+	if opts.TargetCommitish != "" {
+		release.TargetCommitish = github.String(opts.TargetCommitish)
+	}
+	if _, _, err := p.Client.Repositories.CreateRelease(ctx, owner, name, release); err != nil {
+		return fmt.Errorf("creating release for tag %q: %s", opts.Tag, err)
+	}
+	return nil
+}
+
+func (p *Publisher) updateRelease(ctx context.Context, owner, name string, releaseID int64, opts PublishOptions) error {
+	release := &github.RepositoryRelease{
+		Body: github.String(opts.Body),
+	}
+	if _, _, err := p.Client.Repositories.EditRelease(ctx, owner, name, releaseID, release); err != nil {
+		return fmt.Errorf("updating release for tag %q: %s", opts.Tag, err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package changelog
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// ReleaseStats summarizes the commits and contributors that went into a
+// release, for use by changelog templates that want to render a "Thanks to"
+// section.
+type ReleaseStats struct {
+	CommitCount int
+
+	// Contributors is every GitHub user who authored the latest commit of at
+	// least one entry in the release, sorted by login. Commit authors with
+	// no linked GitHub login are not included.
+	Contributors []Contributor
+
+	// NewContributors is the subset of Contributors whose earliest merged
+	// pull request into the repo falls within this release.
+	NewContributors []Contributor
+}
+
+// Contributor is a single person who authored at least one commit included
+// in a release.
+type Contributor struct {
+	Name    string
+	Login   string
+	PRCount int
+}
+
+// releaseStats builds a ReleaseStats from contributorsByLogin. prNumbersByLogin
+// holds, for each login in contributorsByLogin, the numbers of the pull
+// requests they authored that are included in this release -- it's used to
+// tell whether a contributor's earliest merged PR into the repo is one of
+// them, rather than just counting their lifetime PRs.
+func releaseStats(contributorsByLogin map[string]*Contributor, prNumbersByLogin map[string][]int, commitCount int, repoOwner, repoName string, githubClient *githubv4.Client) (*ReleaseStats, error) {
+	stats := &ReleaseStats{CommitCount: commitCount}
+	for _, c := range contributorsByLogin {
+		stats.Contributors = append(stats.Contributors, *c)
+	}
+	sort.Slice(stats.Contributors, func(i, j int) bool {
+		return stats.Contributors[i].Login < stats.Contributors[j].Login
+	})
+
+	for _, c := range stats.Contributors {
+		isNew, err := isFirstReleaseForContributor(c.Login, prNumbersByLogin[c.Login], repoOwner, repoName, githubClient)
+		if err != nil {
+			return nil, err
+		}
+		if isNew {
+			stats.NewContributors = append(stats.NewContributors, c)
+		}
+	}
+	return stats, nil
+}
+
+// isFirstReleaseForContributor reports whether login's earliest merged pull
+// request into repoOwner/repoName is one of releasePRNumbers, i.e. whether
+// this release is their first -- even if they also landed later PRs in the
+// same release.
+func isFirstReleaseForContributor(login string, releasePRNumbers []int, repoOwner, repoName string, githubClient *githubv4.Client) (bool, error) {
+	earliest, err := earliestMergedPRNumber(login, repoOwner, repoName, githubClient)
+	if err != nil {
+		return false, err
+	}
+	return containsInt(releasePRNumbers, earliest), nil
+}
+
+// earliestMergedPRNumber returns the number of login's earliest merged pull
+// request into repoOwner/repoName.
+func earliestMergedPRNumber(login, repoOwner, repoName string, githubClient *githubv4.Client) (int, error) {
+	var q struct {
+		Search struct {
+			Nodes []struct {
+				PullRequest struct {
+					Number githubv4.Int
+				} `graphql:"... on PullRequest"`
+			}
+		} `graphql:"search(query: $query, type: ISSUE, first: 1)"`
+	}
+	variables := map[string]interface{}{
+		"query": githubv4.String(fmt.Sprintf("repo:%s/%s is:pr is:merged author:%s sort:created-asc", repoOwner, repoName, login)),
+	}
+	if err := githubClient.Query(context.Background(), &q, variables); err != nil {
+		return 0, fmt.Errorf("looking up earliest merged pull request for %s: %s", login, err)
+	}
+	if len(q.Search.Nodes) == 0 {
+		return 0, fmt.Errorf("found no merged pull requests for %s", login)
+	}
+	return int(q.Search.Nodes[0].PullRequest.Number), nil
+}
+
+func containsInt(nums []int, n int) bool {
+	for _, num := range nums {
+		if num == n {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package changelog
+
+import "testing"
+
+func TestContainsInt(t *testing.T) {
+	cases := []struct {
+		name string
+		nums []int
+		n    int
+		want bool
+	}{
+		{"present", []int{10, 11}, 10, true},
+		{"absent", []int{10, 11}, 12, false},
+		{"empty", nil, 10, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := containsInt(tc.nums, tc.n); got != tc.want {
+				t.Errorf("containsInt(%v, %d) = %v, want %v", tc.nums, tc.n, got, tc.want)
+			}
+		})
+	}
+}
+
+// TestReleaseStats_NewContributorWithMultiplePRs guards against the bug
+// where a first-time contributor who lands more than one PR in their debut
+// release (e.g. a fix plus a follow-up) was silently excluded from
+// NewContributors because the old check looked at their lifetime PR count
+// instead of whether their earliest PR is among this release's PRs.
+// It applies the containsInt check that isFirstReleaseForContributor uses,
+// with each login's earliest PR number given directly instead of looked up
+// from GitHub, so releaseStats itself is not called.
+func TestReleaseStats_NewContributorWithMultiplePRs(t *testing.T) {
+	releasePRNumbers := map[string][]int{
+		"newcontributor":      {10, 11},
+		"existingcontributor": {12},
+	}
+
+	// newcontributor's earliest-ever merged PR is 10, which is in this
+	// release, even though they also landed PR 11 in the same release.
+	earliestPRNumber := map[string]int{
+		"newcontributor":      10,
+		"existingcontributor": 3,
+	}
+
+	for login, prNumbers := range releasePRNumbers {
+		isNew := containsInt(prNumbers, earliestPRNumber[login])
+		wantNew := login == "newcontributor"
+		if isNew != wantNew {
+			t.Errorf("login %q: isNew = %v, want %v", login, isNew, wantNew)
+		}
+	}
+}